@@ -0,0 +1,218 @@
+// Package alerts delivers operational alerts (provider outages, circuit
+// trips, failed health probes) to real notification backends, replacing
+// the old practice of just printing a message to the console. Sinks are
+// composed via MultiSink and wrapped in a Debouncer so a flapping
+// provider doesn't page or message the same channel once per failure.
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Alert describes a single notification. Service and Reason together
+// identify "the same alert" for debouncing and PagerDuty dedup purposes;
+// Message is the human-readable text shown in the destination channel.
+type Alert struct {
+	Service string
+	Reason  string
+	Message string
+}
+
+// dedupKey returns the key used to decide whether two alerts are "the
+// same" for debouncing and PagerDuty dedup_key purposes.
+func (a Alert) dedupKey() string {
+	return a.Service + ":" + a.Reason
+}
+
+// Sink delivers an Alert to a notification backend.
+type Sink interface {
+	Send(ctx context.Context, a Alert) error
+}
+
+// defaultClient is shared by the HTTP-based sinks below.
+var defaultClient = &http.Client{Timeout: 5 * time.Second}
+
+// SlackSink posts to a Slack incoming webhook.
+type SlackSink struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlackSink builds a SlackSink that posts to webhookURL.
+func NewSlackSink(webhookURL string) *SlackSink {
+	return &SlackSink{webhookURL: webhookURL, client: defaultClient}
+}
+
+func (s *SlackSink) Send(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(map[string]string{"text": a.Message})
+	if err != nil {
+		return fmt.Errorf("alerts: encode slack payload: %w", err)
+	}
+	return postJSON(ctx, s.client, s.webhookURL, body)
+}
+
+// WebhookSink posts the full Alert as JSON to a generic HTTP endpoint.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink builds a WebhookSink that posts to url.
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{url: url, client: defaultClient}
+}
+
+func (w *WebhookSink) Send(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(struct {
+		Service string `json:"service"`
+		Reason  string `json:"reason"`
+		Message string `json:"message"`
+	}{a.Service, a.Reason, a.Message})
+	if err != nil {
+		return fmt.Errorf("alerts: encode webhook payload: %w", err)
+	}
+	return postJSON(ctx, w.client, w.url, body)
+}
+
+// pagerDutyEventsURL is the PagerDuty Events API v2 ingest endpoint.
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutySink triggers PagerDuty Events API v2 incidents, using
+// service+reason as the dedup_key so repeated failures update the same
+// incident instead of opening a new one each time.
+type PagerDutySink struct {
+	routingKey string
+	client     *http.Client
+}
+
+// NewPagerDutySink builds a PagerDutySink that triggers events under routingKey.
+func NewPagerDutySink(routingKey string) *PagerDutySink {
+	return &PagerDutySink{routingKey: routingKey, client: defaultClient}
+}
+
+func (p *PagerDutySink) Send(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    a.dedupKey(),
+		"payload": map[string]string{
+			"summary":  a.Message,
+			"source":   a.Service,
+			"severity": "critical",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("alerts: encode pagerduty payload: %w", err)
+	}
+	return postJSON(ctx, p.client, pagerDutyEventsURL, body)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("alerts: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("alerts: send to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alerts: %s returned status %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// MultiSink fans an Alert out to every Sink it holds, continuing past
+// individual failures and returning their combined error, if any.
+type MultiSink []Sink
+
+func (m MultiSink) Send(ctx context.Context, a Alert) error {
+	var errs []error
+	for _, sink := range m {
+		if err := sink.Send(ctx, a); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NoopSink discards every alert. It's the fallback when no backend is configured.
+type NoopSink struct{}
+
+func (NoopSink) Send(ctx context.Context, a Alert) error { return nil }
+
+// Debouncer wraps a Sink and drops alerts that repeat the same
+// service+reason within window of a prior delivery, so a flapping
+// provider doesn't spam the destination once per failure.
+type Debouncer struct {
+	sink   Sink
+	window time.Duration
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// NewDebouncer wraps sink so repeated alerts for the same service+reason
+// within window collapse into a single delivery.
+func NewDebouncer(sink Sink, window time.Duration) *Debouncer {
+	return &Debouncer{sink: sink, window: window, last: make(map[string]time.Time)}
+}
+
+func (d *Debouncer) Send(ctx context.Context, a Alert) error {
+	key := a.dedupKey()
+	now := time.Now()
+
+	d.mu.Lock()
+	if last, ok := d.last[key]; ok && now.Sub(last) < d.window {
+		d.mu.Unlock()
+		return nil
+	}
+	d.last[key] = now
+	d.mu.Unlock()
+
+	return d.sink.Send(ctx, a)
+}
+
+// defaultDebounceWindow is how long repeated alerts for the same
+// service+reason collapse into one when ALERT_DEBOUNCE_SECONDS isn't set.
+const defaultDebounceWindow = 5 * time.Minute
+
+// NewFromEnv builds a debounced MultiSink from whichever backends are
+// configured via SLACK_WEBHOOK_URL, ALERT_WEBHOOK_URL, and
+// PAGERDUTY_ROUTING_KEY. The debounce window defaults to 5 minutes and
+// can be overridden with ALERT_DEBOUNCE_SECONDS. If no backend is
+// configured, it returns a NoopSink.
+func NewFromEnv() Sink {
+	var sinks MultiSink
+	if url := os.Getenv("SLACK_WEBHOOK_URL"); url != "" {
+		sinks = append(sinks, NewSlackSink(url))
+	}
+	if url := os.Getenv("ALERT_WEBHOOK_URL"); url != "" {
+		sinks = append(sinks, NewWebhookSink(url))
+	}
+	if key := os.Getenv("PAGERDUTY_ROUTING_KEY"); key != "" {
+		sinks = append(sinks, NewPagerDutySink(key))
+	}
+	if len(sinks) == 0 {
+		return NoopSink{}
+	}
+
+	window := defaultDebounceWindow
+	if secs, err := strconv.Atoi(os.Getenv("ALERT_DEBOUNCE_SECONDS")); err == nil && secs > 0 {
+		window = time.Duration(secs) * time.Second
+	}
+	return NewDebouncer(sinks, window)
+}
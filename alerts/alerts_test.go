@@ -0,0 +1,76 @@
+package alerts
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeSink struct {
+	sent []Alert
+	err  error
+}
+
+func (f *fakeSink) Send(ctx context.Context, a Alert) error {
+	f.sent = append(f.sent, a)
+	return f.err
+}
+
+func TestMultiSinkFansOutToEverySink(t *testing.T) {
+	a, b := &fakeSink{}, &fakeSink{}
+	multi := MultiSink{a, b}
+
+	alert := Alert{Service: "api-proxy", Reason: "circuit_open", Message: "boom"}
+	if err := multi.Send(context.Background(), alert); err != nil {
+		t.Fatalf("Send() error = %v, want nil", err)
+	}
+	if len(a.sent) != 1 || len(b.sent) != 1 {
+		t.Errorf("sent = %d, %d want 1, 1", len(a.sent), len(b.sent))
+	}
+}
+
+func TestMultiSinkContinuesPastFailures(t *testing.T) {
+	failing := &fakeSink{err: errBoom}
+	ok := &fakeSink{}
+	multi := MultiSink{failing, ok}
+
+	err := multi.Send(context.Background(), Alert{Service: "s", Reason: "r"})
+	if err == nil {
+		t.Fatal("Send() error = nil, want non-nil")
+	}
+	if len(ok.sent) != 1 {
+		t.Errorf("second sink received %d alerts, want 1", len(ok.sent))
+	}
+}
+
+func TestDebouncerCollapsesRepeatsWithinWindow(t *testing.T) {
+	sink := &fakeSink{}
+	d := NewDebouncer(sink, time.Hour)
+
+	alert := Alert{Service: "api-proxy", Reason: "circuit_open", Message: "first"}
+	for i := 0; i < 3; i++ {
+		if err := d.Send(context.Background(), alert); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+	if len(sink.sent) != 1 {
+		t.Errorf("sink received %d alerts, want 1", len(sink.sent))
+	}
+}
+
+func TestDebouncerAllowsDifferentReasons(t *testing.T) {
+	sink := &fakeSink{}
+	d := NewDebouncer(sink, time.Hour)
+
+	d.Send(context.Background(), Alert{Service: "api-proxy", Reason: "circuit_open"})
+	d.Send(context.Background(), Alert{Service: "api-proxy", Reason: "cache_miss_storm"})
+	if len(sink.sent) != 2 {
+		t.Errorf("sink received %d alerts, want 2", len(sink.sent))
+	}
+}
+
+var errBoom = errBoomType{}
+
+type errBoomType struct{}
+
+func (errBoomType) Error() string { return "boom" }
@@ -4,35 +4,178 @@ import (
 	"context"       // used for managing cancellation, deadlines (used with Redis)
 	"encoding/json" // for converting Go data to JSON and vice versa
 	"fmt"           // for formatted I/O like Printf, Sprintf, etc.
-	"log"           // for logging information and errors
+	"log/slog"      // structured logging
+	"net"           // for splitting host:port out of RemoteAddr
 	"net/http"      // for building HTTP servers and clients
 	"os"            // for interacting with the environment, like getting env variables
+	"strconv"       // for parsing HEDGE_DELAY_MS
+	"strings"       // for comparing REDIS_MODE case-insensitively
 	"time"          // for time operations like delays, timeouts, timestamps
 
-	"github.com/redis/go-redis/v9" // Redis client package
+	"github.com/redis/go-redis/v9"   // Redis client package
+	"golang.org/x/sync/singleflight" // coalesces concurrent identical requests
+
+	"github.com/DwaipayanSom/Resilient-API-Proxy-System/alerts"         // real alert backends (Slack, PagerDuty, webhook)
+	"github.com/DwaipayanSom/Resilient-API-Proxy-System/cache"          // Redis-backed response cache with stale-while-revalidate
+	"github.com/DwaipayanSom/Resilient-API-Proxy-System/circuitbreaker" // Redis-backed circuit breaker state
+	"github.com/DwaipayanSom/Resilient-API-Proxy-System/logging"        // structured JSON loggers and request ids
+	"github.com/DwaipayanSom/Resilient-API-Proxy-System/metrics"        // Prometheus collectors
+	"github.com/DwaipayanSom/Resilient-API-Proxy-System/providers"      // pluggable, config-driven provider adapters
+	"github.com/DwaipayanSom/Resilient-API-Proxy-System/ratelimit"      // distributed token-bucket rate limiting
+	"github.com/DwaipayanSom/Resilient-API-Proxy-System/redisconn"      // builds the Redis client from REDIS_MODE and friends
 )
 
+// defaultHedgeDelay is how long the primary provider gets to answer
+// before the next-highest-weight provider is fired in parallel.
+const defaultHedgeDelay = 300 * time.Millisecond
+
 // Declare global variables
 var (
-	activeAPI      = "openweathermap"                                        // currently preferred API provider
-	inactiveAPIs   = map[string]bool{"openweathermap": false, "wttr": false} // keep track of disabled APIs
-	openWeatherKey = os.Getenv("OPENWEATHER_API_KEY")                        // get API key from environment variable
-	redisClient    *redis.Client                                             // Redis client instance (will be initialized later)
+	inactiveAPIs = map[string]bool{} // manual kill switch per provider name, e.g. via an ops endpoint
+
+	redisClient   redis.UniversalClient  // Redis client instance (will be initialized later)
+	clusterMode   bool                   // true when REDIS_MODE=cluster, so pub/sub must use the sharded commands
+	breaker       circuitbreaker.Breaker // shared, Redis-backed circuit breaker state
+	registry      *providers.Registry    // configured provider adapters
+	hedgeDelay    = defaultHedgeDelay    // how long to wait before hedging to the next provider
+	weatherCache  *cache.Cache           // Redis-backed response cache
+	weatherSingle singleflight.Group     // coalesces concurrent upstream fetches for the same city
+
+	clientLimiter   *ratelimit.Limiter // per-client (IP or X-API-Key) quota
+	providerLimiter *ratelimit.Limiter // per-provider quota, protects upstream API budgets
+
+	logger    *slog.Logger      // structured JSON logger, level set by LOG_LEVEL
+	mtx       *metrics.APIProxy // Prometheus collectors
+	alertSink alerts.Sink       // debounced fan-out to whichever backends are configured
 )
 
 func main() {
-	// Connect to the Redis server running in the 'redis' container (via Docker Compose)
-	redisClient = redis.NewClient(&redis.Options{
-		Addr: "redis:6379", // Redis hostname and port inside the Docker network
-	})
+	logger = logging.New("api-proxy")
+	alertSink = alerts.NewFromEnv()
+
+	// Connect to Redis according to REDIS_MODE (single, sentinel, or cluster)
+	ctx := context.Background()
+	var err error
+	redisClient, err = redisconn.NewUniversalClient(ctx)
+	if err != nil {
+		logger.Error("failed to connect to Redis", "error", err)
+		os.Exit(1)
+	}
+	clusterMode = strings.EqualFold(os.Getenv("REDIS_MODE"), "cluster")
+	breaker = circuitbreaker.NewRedisBreaker(redisClient, circuitbreaker.WithClusterMode(clusterMode))
 	defer redisClient.Close() // ensure connection is closed when the program exits
 
+	registry, err = loadProviderRegistry()
+	if err != nil {
+		logger.Error("failed to load provider registry", "error", err)
+		os.Exit(1)
+	}
+	if ms, perr := strconv.Atoi(os.Getenv("HEDGE_DELAY_MS")); perr == nil && ms > 0 {
+		hedgeDelay = time.Duration(ms) * time.Millisecond
+	}
+
+	var cacheOpts []cache.Option
+	if secs, perr := strconv.Atoi(os.Getenv("CACHE_TTL_SECONDS")); perr == nil && secs > 0 {
+		cacheOpts = append(cacheOpts, cache.WithTTL(time.Duration(secs)*time.Second))
+	}
+	if secs, perr := strconv.Atoi(os.Getenv("CACHE_STALE_WINDOW_SECONDS")); perr == nil && secs > 0 {
+		cacheOpts = append(cacheOpts, cache.WithStaleWindow(time.Duration(secs)*time.Second))
+	}
+	weatherCache = cache.New(redisClient, cacheOpts...)
+
+	clientLimiter = ratelimit.New(redisClient, "client",
+		ratelimit.WithBurst(envInt64("RATE_LIMIT_CLIENT_BURST", 20)),
+		ratelimit.WithRefillRate(envFloat("RATE_LIMIT_CLIENT_REFILL_PER_SEC", 5)))
+	providerLimiter = ratelimit.New(redisClient, "provider",
+		ratelimit.WithBurst(envInt64("RATE_LIMIT_PROVIDER_BURST", 60)),
+		ratelimit.WithRefillRate(envFloat("RATE_LIMIT_PROVIDER_REFILL_PER_SEC", 10)))
+
+	mtx = metrics.NewAPIProxy()
+
 	// Register HTTP route handlers
-	http.HandleFunc("/weather", weatherHandler) // handles requests to get weather
-	http.HandleFunc("/health", healthHandler)   // handles health check requests
+	http.HandleFunc("/weather", requestIDMiddleware(rateLimitMiddleware(weatherHandler))) // handles requests to get weather, quota-gated per client
+	http.HandleFunc("/health", healthHandler)                                             // handles health check requests
+	http.Handle("/metrics", metrics.Handler())                                            // exposes Prometheus collectors
+
+	logger.Info("API Proxy running", "addr", ":8080")
+	logFatal(http.ListenAndServe(":8080", nil)) // start the HTTP server on port 8080
+}
+
+// logFatal logs err (if any) and exits, mirroring log.Fatal but through
+// the structured logger so server startup failures are JSON too.
+func logFatal(err error) {
+	if err != nil {
+		logger.Error("server exited", "error", err)
+		os.Exit(1)
+	}
+}
+
+// envInt64 reads an integer env var, falling back to def if unset or invalid.
+func envInt64(name string, def int64) int64 {
+	v, err := strconv.ParseInt(os.Getenv(name), 10, 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// envFloat reads a float env var, falling back to def if unset or invalid.
+func envFloat(name string, def float64) float64 {
+	v, err := strconv.ParseFloat(os.Getenv(name), 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
 
-	log.Println("API Proxy running on :8080")    // print a message to the console
-	log.Fatal(http.ListenAndServe(":8080", nil)) // start the HTTP server on port 8080
+// requestIDMiddleware stamps every request with a short id used to
+// correlate its log lines, reusing an inbound X-Request-ID if present.
+func requestIDMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" {
+			id = logging.NewRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		next(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	}
+}
+
+// rateLimitMiddleware enforces the per-client token bucket before a
+// request reaches the handler, returning 429 with Retry-After and
+// X-RateLimit-Remaining when the caller's bucket is empty.
+func rateLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := clientLimiter.Allow(r.Context(), clientKey(r), 1)
+		if err != nil {
+			logger.Error("error checking client rate limit", "error", err)
+			next(w, r)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+		if !result.Allowed {
+			retryAfterSeconds := (result.WaitMS + 999) / 1000 // round up to whole seconds
+			w.Header().Set("Retry-After", strconv.FormatInt(retryAfterSeconds, 10))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// clientKey identifies the caller for rate limiting: the X-API-Key
+// header if present, otherwise the request's source IP.
+func clientKey(r *http.Request) string {
+	if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+		return "key:" + apiKey
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
 }
 
 // Basic health check endpoint to confirm service is alive
@@ -50,10 +193,18 @@ func weatherHandler(w http.ResponseWriter, r *http.Request) {
 		return // exit early if city parameter is missing
 	}
 
-	// Fetch weather data using the `getWeatherData` function
-	data, err := getWeatherData(city)
+	noCache := r.URL.Query().Get("nocache") == "1" // ?nocache=1 bypasses the cache for debugging
+
+	start := time.Now()
+	reqLogger := logger.With("request_id", logging.RequestID(r.Context()), "city", city)
+
+	// Fetch weather data, serving from cache (or a stale fallback) where possible
+	data, freshness, err := resolveWeather(r.Context(), city, noCache)
+	w.Header().Set("X-Cache", freshness.String())
 	if err != nil {
-		// If all providers fail, return a dummy fallback response
+		// If all providers fail and we have nothing cached, return a dummy fallback response
+		reqLogger.Warn("all providers failed", "error", err, "latency", time.Since(start))
+		mtx.RequestsTotal.WithLabelValues("none", "failure").Inc()
 		response := map[string]string{
 			"weather": "unavailable",
 			"note":    "all providers failed, returning stubbed response",
@@ -63,94 +214,177 @@ func weatherHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	reqLogger.Info("served weather response", "provider", data.Provider, "cache", freshness.String(), "latency", time.Since(start))
+	mtx.RequestDuration.WithLabelValues(data.Provider).Observe(time.Since(start).Seconds())
+	mtx.RequestsTotal.WithLabelValues(data.Provider, "success").Inc()
+
 	// Send the actual weather data as a JSON response
 	json.NewEncoder(w).Encode(data)
 }
 
-// Variables to track circuit breaker state for each provider
-var (
-	circuitState    = map[string]string{"openweathermap": "closed", "wttr": "closed"}          // current state: open, closed, half-open
-	failureCount    = map[string]int{"openweathermap": 0, "wttr": 0}                           // failure count for each API
-	lastFailureTime = map[string]time.Time{"openweathermap": time.Time{}, "wttr": time.Time{}} // last time each API failed
-)
-
-// Tries to get weather data from multiple providers with circuit breaker logic
-func getWeatherData(city string) (map[string]interface{}, error) {
+// resolveWeather serves a fresh cache hit if one exists, otherwise
+// coalesces concurrent callers for the same city behind a singleflight
+// call to the providers, caching the result on success. If every
+// provider fails, it falls back to the first stale entry found in
+// provider priority order.
+func resolveWeather(ctx context.Context, city string, noCache bool) (providers.NormalizedWeather, cache.Freshness, error) {
+	if noCache {
+		weather, err := getWeatherData(ctx, city)
+		return weather, cache.Miss, err
+	}
 
-	apis := []string{"openweathermap", "wttr"} // list of APIs to try in order
+	if entry, freshness, ok := weatherCache.Best(ctx, providerNames(), city); ok && freshness == cache.Hit {
+		return entry.Weather, cache.Hit, nil
+	}
 
-	for _, api := range apis {
-		// Skip inactive APIs
-		if inactiveAPIs[api] {
-			continue
+	v, err, _ := weatherSingle.Do(city, func() (interface{}, error) {
+		weather, ferr := getWeatherData(ctx, city)
+		if ferr != nil {
+			return providers.NormalizedWeather{}, ferr
 		}
-
-		// Check if circuit is open (i.e., temporarily blocking this API)
-		state := circuitState[api]
-		if state == "open" {
-			// If still within cooldown period, skip this API
-			if time.Since(lastFailureTime[api]) < 30*time.Second {
-				log.Printf("⛔ Circuit open for %s — skipping", api)
-				continue
-			}
-			// After cooldown, attempt a retry in half-open state
-			log.Printf("🔄 Circuit half-open for %s — retrying...", api)
-			circuitState[api] = "half-open"
+		if cerr := weatherCache.Set(ctx, weather.Provider, city, weather); cerr != nil {
+			logger.Error("error writing weather cache", "error", cerr)
 		}
+		return weather, nil
+	})
+	if err == nil {
+		return v.(providers.NormalizedWeather), cache.Miss, nil
+	}
 
-		// Build the API request URL depending on provider
-		var url string
-		if api == "openweathermap" {
-			url = fmt.Sprintf("https://api.openweathermap.org/data/2.5/weather?q=%s&appid=%s", city, openWeatherKey)
-		} else {
-			url = fmt.Sprintf("https://wttr.in/%s?format=j1", city)
-		}
+	if entry, freshness, ok := weatherCache.Best(ctx, providerNames(), city); ok && freshness == cache.Stale {
+		return entry.Weather, cache.Stale, nil
+	}
+	return providers.NormalizedWeather{}, cache.Miss, err
+}
 
-		// Create a new HTTP client with timeout
-		client := http.Client{Timeout: 5 * time.Second}
-		resp, err := client.Get(url) // send the GET request
-
-		// Handle failed request or non-200 status code
-		if err != nil || resp.StatusCode != 200 {
-			log.Printf("⚠️ API %s failed: %v", api, err)
-			failureCount[api]++               // increase failure count
-			lastFailureTime[api] = time.Now() // record when the failure happened
-
-			// If this API fails 3 times in a row, open the circuit
-			if failureCount[api] >= 3 {
-				circuitState[api] = "open"
-				publishStatus(fmt.Sprintf("🚫 Circuit opened for %s after 3 failures", api))
-			} else {
-				// Otherwise just log the failure attempt
-				publishStatus(fmt.Sprintf("⚠️ Failure %d for %s", failureCount[api], api))
-			}
-
-			continue // try the next API in the list
-		}
+// providerNames returns the registry's provider names in priority order,
+// used to probe the cache across every provider for a given city.
+func providerNames() []string {
+	ps := registry.Providers()
+	names := make([]string, len(ps))
+	for i, p := range ps {
+		names[i] = p.Name()
+	}
+	return names
+}
+
+// loadProviderRegistry reads the provider config named by
+// PROVIDERS_CONFIG_PATH (default config/providers.yaml) and builds a
+// Registry from it.
+func loadProviderRegistry() (*providers.Registry, error) {
+	path := os.Getenv("PROVIDERS_CONFIG_PATH")
+	if path == "" {
+		path = "config/providers.yaml"
+	}
+	cfg, err := providers.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return providers.NewRegistry(cfg)
+}
+
+// inactiveAwareGate layers the manual inactiveAPIs kill switch and the
+// per-provider rate limit on top of the Redis-backed circuit breaker
+// before the registry decides which providers it's allowed to call, and
+// keeps the apiproxy_circuit_state and apiproxy_provider_failures_total
+// metrics in sync with every outcome. Allow only filters on the kill
+// switch and circuit state; Reserve spends the per-provider rate limit
+// token, and the registry only calls it for providers it actually
+// launches, so a hedge/fallback provider that's never fetched never
+// burns its quota.
+type inactiveAwareGate struct {
+	circuitbreaker.Breaker
+}
+
+func (g inactiveAwareGate) Allow(ctx context.Context, provider string) (bool, bool, error) {
+	if inactiveAPIs[provider] {
+		return false, false, nil
+	}
+	return g.Breaker.Allow(ctx, provider)
+}
+
+func (g inactiveAwareGate) Reserve(ctx context.Context, provider string) (bool, error) {
+	limited, err := providerLimiter.Allow(ctx, provider, 1)
+	if err != nil {
+		logger.Error("error checking provider rate limit", "provider", provider, "error", err)
+		return true, nil
+	}
+	return limited.Allowed, nil
+}
 
-		defer resp.Body.Close() // ensure response body gets closed
+func (g inactiveAwareGate) RecordSuccess(ctx context.Context, provider string) error {
+	err := g.Breaker.RecordSuccess(ctx, provider)
+	observeCircuitState(ctx, provider)
+	return err
+}
 
-		// On successful response: reset failure tracking
-		failureCount[api] = 0
-		circuitState[api] = "closed"
-		publishStatus(fmt.Sprintf("✅ Circuit closed for %s — success!", api))
+func (g inactiveAwareGate) RecordFailure(ctx context.Context, provider string) error {
+	err := g.Breaker.RecordFailure(ctx, provider)
+	observeCircuitState(ctx, provider)
+	mtx.ProviderFailures.WithLabelValues(provider).Inc()
+	return err
+}
 
-		// Decode JSON body into a generic map
-		var result map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&result)
-		publishStatus(fmt.Sprintf("✅ Success from %s", api))
-		return result, nil // return successful data
+// observeCircuitState reads the breaker's current state for provider and
+// updates the apiproxy_circuit_state gauge to match.
+func observeCircuitState(ctx context.Context, provider string) {
+	state, err := breaker.State(ctx, provider)
+	if err != nil {
+		logger.Error("error reading circuit state for metrics", "provider", provider, "error", err)
+		return
 	}
 
-	// If all APIs failed, return an error
-	return nil, fmt.Errorf("all APIs failed")
+	value := float64(metrics.CircuitClosed)
+	switch state {
+	case circuitbreaker.StateOpen:
+		value = metrics.CircuitOpen
+		sendAlert("circuit_open:"+provider, fmt.Sprintf("Circuit opened for provider %s", provider))
+	case circuitbreaker.StateHalfOpen:
+		value = metrics.CircuitHalfOpen
+	}
+	mtx.CircuitState.WithLabelValues(provider).Set(value)
+}
+
+// sendAlert delivers msg to the configured alert sink(s), tagging it with
+// reason so repeated alerts of the same kind debounce against each other
+// instead of paging once per request.
+func sendAlert(reason, msg string) {
+	alert := alerts.Alert{Service: "api-proxy", Reason: reason, Message: msg}
+	if err := alertSink.Send(context.Background(), alert); err != nil {
+		logger.Error("failed to send alert", "reason", reason, "error", err)
+	}
 }
 
-// Publishes a status update message to Redis channel for others to subscribe
+// Tries to get weather data from the configured providers, hedging to the
+// next-highest-weight provider if the primary is slow, and skipping any
+// provider whose circuit is open. Circuit state lives in Redis (see
+// circuitbreaker package) so it's shared across every api-proxy replica
+// and survives restarts.
+func getWeatherData(ctx context.Context, city string) (providers.NormalizedWeather, error) {
+	weather, err := registry.FetchHedged(ctx, city, inactiveAwareGate{breaker}, hedgeDelay)
+	if err != nil {
+		publishStatus(fmt.Sprintf("⚠️ All providers failed for %s: %v", city, err))
+		sendAlert("all_providers_failed", fmt.Sprintf("All providers failed for %s: %v", city, err))
+		return providers.NormalizedWeather{}, fmt.Errorf("all APIs failed: %w", err)
+	}
+
+	publishStatus(fmt.Sprintf("✅ Success from %s", weather.Provider))
+	return weather, nil
+}
+
+// Publishes a status update message to Redis channel for others to subscribe.
+// In cluster mode this uses SPUBLISH so the message reaches the shard that
+// owns "status_channel" no matter which node we're connected to.
 func publishStatus(message string) {
-	ctx := context.Background()                                      // create a background context
-	err := redisClient.Publish(ctx, "status_channel", message).Err() // publish to "status_channel"
+	ctx := context.Background() // create a background context
+
+	var err error
+	if clusterMode {
+		err = redisClient.SPublish(ctx, "status_channel", message).Err()
+	} else {
+		err = redisClient.Publish(ctx, "status_channel", message).Err()
+	}
 	if err != nil {
-		log.Println("Error publishing to Redis:", err) // log any publish failure
+		logger.Error("error publishing to Redis", "error", err)
 	}
 }
@@ -0,0 +1,178 @@
+// Package cache fronts the provider registry with a Redis-backed
+// response cache. Entries carry a fresh TTL and a longer
+// stale-while-revalidate window, so when every provider is down the
+// handler can still serve the last known good response instead of a stub.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/DwaipayanSom/Resilient-API-Proxy-System/providers"
+)
+
+// Freshness describes how an Entry relates to its TTL and stale window.
+type Freshness int
+
+const (
+	Miss Freshness = iota
+	Hit
+	Stale
+)
+
+// String matches the X-Cache header values the handler exposes.
+func (f Freshness) String() string {
+	switch f {
+	case Hit:
+		return "HIT"
+	case Stale:
+		return "STALE"
+	default:
+		return "MISS"
+	}
+}
+
+// Entry is a cached provider response plus the metadata needed to judge
+// its freshness later.
+type Entry struct {
+	Weather   providers.NormalizedWeather
+	Provider  string
+	FetchedAt time.Time
+	TTL       time.Duration
+}
+
+// Cache is a Redis-backed cache of provider responses, keyed per
+// provider+city so a stale response from one provider is never confused
+// with a fresher one from another.
+type Cache struct {
+	client      redis.UniversalClient
+	ttl         time.Duration
+	staleWindow time.Duration
+}
+
+// Option configures a Cache.
+type Option func(*Cache)
+
+// WithTTL sets how long an entry is served as a HIT. Default 60s.
+func WithTTL(d time.Duration) Option {
+	return func(c *Cache) { c.ttl = d }
+}
+
+// WithStaleWindow sets how long after it was fetched an entry is still
+// servable as a STALE fallback once its TTL has passed. Like the TTL, it
+// is measured from the fetch time, not from the end of the TTL. Default
+// 5 minutes.
+func WithStaleWindow(d time.Duration) Option {
+	return func(c *Cache) { c.staleWindow = d }
+}
+
+// New builds a Cache on top of client.
+func New(client redis.UniversalClient, opts ...Option) *Cache {
+	c := &Cache{
+		client:      client,
+		ttl:         60 * time.Second,
+		staleWindow: 5 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func cacheKey(provider, city string) string {
+	return fmt.Sprintf("weather:%s:%s", provider, strings.ToLower(city))
+}
+
+// Get returns the cached entry for provider+city, if any, along with
+// whether it's still fresh, merely stale, or effectively a miss.
+func (c *Cache) Get(ctx context.Context, provider, city string) (Entry, Freshness, error) {
+	fields, err := c.client.HGetAll(ctx, cacheKey(provider, city)).Result()
+	if err != nil {
+		return Entry{}, Miss, fmt.Errorf("cache: get %s/%s: %w", provider, city, err)
+	}
+	if len(fields) == 0 {
+		return Entry{}, Miss, nil
+	}
+
+	var weather providers.NormalizedWeather
+	if err := json.Unmarshal([]byte(fields["payload"]), &weather); err != nil {
+		return Entry{}, Miss, fmt.Errorf("cache: decode payload for %s/%s: %w", provider, city, err)
+	}
+	fetchedUnix, _ := strconv.ParseInt(fields["fetched_at"], 10, 64)
+	ttlSeconds, _ := strconv.ParseInt(fields["ttl_seconds"], 10, 64)
+
+	entry := Entry{
+		Weather:   weather,
+		Provider:  fields["provider"],
+		FetchedAt: time.Unix(fetchedUnix, 0),
+		TTL:       time.Duration(ttlSeconds) * time.Second,
+	}
+
+	age := time.Since(entry.FetchedAt)
+	switch {
+	case age <= entry.TTL:
+		return entry, Hit, nil
+	case age <= c.staleWindow:
+		return entry, Stale, nil
+	default:
+		return Entry{}, Miss, nil
+	}
+}
+
+// Set stores weather under provider+city, marked fresh for the cache's
+// configured TTL. The Redis key expires after the stale window so
+// entries too old to serve even as a fallback are purged automatically.
+func (c *Cache) Set(ctx context.Context, provider, city string, weather providers.NormalizedWeather) error {
+	payload, err := json.Marshal(weather)
+	if err != nil {
+		return fmt.Errorf("cache: marshal payload for %s/%s: %w", provider, city, err)
+	}
+
+	key := cacheKey(provider, city)
+	err = c.client.HSet(ctx, key, map[string]interface{}{
+		"payload":     payload,
+		"fetched_at":  time.Now().Unix(),
+		"provider":    provider,
+		"ttl_seconds": int64(c.ttl.Seconds()),
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("cache: set %s/%s: %w", provider, city, err)
+	}
+
+	if err := c.client.Expire(ctx, key, c.staleWindow).Err(); err != nil {
+		return fmt.Errorf("cache: expire %s/%s: %w", provider, city, err)
+	}
+	return nil
+}
+
+// Best scans provider+city entries in the given provider priority order
+// and returns the best one available: the first fresh Hit, or failing
+// that the first Stale entry. ok is false if nothing usable was found.
+func (c *Cache) Best(ctx context.Context, providerNames []string, city string) (entry Entry, freshness Freshness, ok bool) {
+	var bestStale Entry
+	haveStale := false
+
+	for _, name := range providerNames {
+		e, fr, err := c.Get(ctx, name, city)
+		if err != nil || fr == Miss {
+			continue
+		}
+		if fr == Hit {
+			return e, Hit, true
+		}
+		if !haveStale {
+			bestStale, haveStale = e, true
+		}
+	}
+
+	if haveStale {
+		return bestStale, Stale, true
+	}
+	return Entry{}, Miss, false
+}
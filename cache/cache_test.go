@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/DwaipayanSom/Resilient-API-Proxy-System/providers"
+)
+
+func TestFreshnessString(t *testing.T) {
+	cases := map[Freshness]string{
+		Hit:   "HIT",
+		Stale: "STALE",
+		Miss:  "MISS",
+	}
+	for freshness, want := range cases {
+		if got := freshness.String(); got != want {
+			t.Errorf("Freshness(%d).String() = %q, want %q", freshness, got, want)
+		}
+	}
+}
+
+func TestCacheKeyLowercasesCity(t *testing.T) {
+	if got, want := cacheKey("openweathermap", "London"), "weather:openweathermap:london"; got != want {
+		t.Errorf("cacheKey() = %q, want %q", got, want)
+	}
+}
+
+// newTestClient connects to REDIS_ADDR for the Get/Set freshness-boundary
+// tests below, which need a real Redis to exercise HSET/EXPIRE. Skipped
+// when REDIS_ADDR isn't set (e.g. this repo's default sandboxed CI).
+func newTestClient(t *testing.T) redis.UniversalClient {
+	t.Helper()
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR not set, skipping Redis integration test")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("could not reach Redis at %s: %v", addr, err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestCacheGetSetRoundTripsWithinTTL(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	t.Cleanup(func() { client.Del(ctx, cacheKey("openweathermap", "london")) })
+
+	c := New(client, WithTTL(time.Minute), WithStaleWindow(5*time.Minute))
+	weather := providers.NormalizedWeather{City: "London", TemperatureC: 12.5, Condition: "cloudy", Provider: "openweathermap"}
+
+	if err := c.Set(ctx, "openweathermap", "London", weather); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	entry, freshness, err := c.Get(ctx, "openweathermap", "London")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if freshness != Hit {
+		t.Errorf("Get() freshness = %v, want %v", freshness, Hit)
+	}
+	if entry.Weather.City != weather.City || entry.Weather.TemperatureC != weather.TemperatureC {
+		t.Errorf("Get() weather = %+v, want %+v", entry.Weather, weather)
+	}
+}
+
+func TestCacheGetIsStaleAfterTTLButWithinStaleWindow(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	t.Cleanup(func() { client.Del(ctx, cacheKey("openweathermap", "paris")) })
+
+	c := New(client, WithTTL(0), WithStaleWindow(time.Minute))
+	weather := providers.NormalizedWeather{City: "Paris", TemperatureC: 18, Condition: "sunny", Provider: "openweathermap"}
+	if err := c.Set(ctx, "openweathermap", "Paris", weather); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	_, freshness, err := c.Get(ctx, "openweathermap", "Paris")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if freshness != Stale {
+		t.Errorf("Get() freshness = %v, want %v (TTL 0 should already have elapsed)", freshness, Stale)
+	}
+}
+
+func TestCacheGetIsMissPastStaleWindow(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	t.Cleanup(func() { client.Del(ctx, cacheKey("openweathermap", "berlin")) })
+
+	c := New(client, WithTTL(0), WithStaleWindow(0))
+	weather := providers.NormalizedWeather{City: "Berlin", TemperatureC: 5, Condition: "rain", Provider: "openweathermap"}
+	if err := c.Set(ctx, "openweathermap", "Berlin", weather); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	_, freshness, err := c.Get(ctx, "openweathermap", "Berlin")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if freshness != Miss {
+		t.Errorf("Get() freshness = %v, want %v (past stale window)", freshness, Miss)
+	}
+}
@@ -0,0 +1,279 @@
+// Package circuitbreaker tracks per-provider circuit breaker state in
+// Redis instead of process memory, so a horizontally scaled api-proxy
+// shares one view of "is this provider healthy" and a restart doesn't
+// forget that a circuit was open. All state transitions run as Lua
+// scripts so that concurrent replicas CAS against the same hash instead
+// of racing each other, and exactly one replica wins the half-open probe.
+package circuitbreaker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// State mirrors the classic closed/open/half-open circuit breaker states.
+type State string
+
+const (
+	StateClosed   State = "closed"
+	StateOpen     State = "open"
+	StateHalfOpen State = "half-open"
+)
+
+// EventsChannel is the Pub/Sub channel circuit state transitions are
+// published on, so the health-checker (or any alerting consumer) can
+// react without polling Redis.
+const EventsChannel = "circuit_events"
+
+// Event is published to EventsChannel whenever a provider's state changes.
+// At is a Unix timestamp (seconds) rather than time.Time, the same clock
+// the Lua scripts store in last_failure.
+type Event struct {
+	Provider string `json:"provider"`
+	State    State  `json:"state"`
+	Failures int64  `json:"failures"`
+	At       int64  `json:"at"`
+}
+
+// Breaker decides whether a call to a provider may proceed and records
+// the outcome. Implementations must be safe for concurrent use by
+// multiple api-proxy replicas.
+type Breaker interface {
+	// Allow reports whether a request to provider may proceed. probe is
+	// true when this call is the single half-open trial request — the
+	// caller must call RecordSuccess or RecordFailure based on its outcome.
+	Allow(ctx context.Context, provider string) (allowed bool, probe bool, err error)
+	// RecordSuccess closes the circuit for provider and resets its failure count.
+	RecordSuccess(ctx context.Context, provider string) error
+	// RecordFailure records a failure for provider, opening the circuit
+	// once the configured threshold is reached.
+	RecordFailure(ctx context.Context, provider string) error
+	// State returns the current state for provider (StateClosed if no
+	// state has been recorded yet), for reporting via metrics.
+	State(ctx context.Context, provider string) (State, error)
+}
+
+// RedisBreaker is the Redis-backed Breaker implementation. Zero value is
+// not usable; construct with NewRedisBreaker.
+type RedisBreaker struct {
+	client      redis.UniversalClient
+	threshold   int64
+	cooldown    time.Duration
+	clusterMode bool
+}
+
+// Option configures a RedisBreaker.
+type Option func(*RedisBreaker)
+
+// WithThreshold sets how many consecutive failures open the circuit. Default 3.
+func WithThreshold(n int64) Option {
+	return func(b *RedisBreaker) { b.threshold = n }
+}
+
+// WithCooldown sets how long an open circuit waits before allowing a
+// half-open probe. Default 30s.
+func WithCooldown(d time.Duration) Option {
+	return func(b *RedisBreaker) { b.cooldown = d }
+}
+
+// WithClusterMode marks client as talking to a Redis Cluster, so
+// circuit_events is published with SPUBLISH instead of PUBLISH. Plain
+// PUBLISH and the sharded SPUBLISH/SSUBSCRIBE are two independent
+// pub/sub systems in Redis — a subscriber that SSUBSCRIBEs (as the
+// health-checker does in cluster mode) never sees a plain PUBLISH, so
+// this must match the mode the subscriber was started with.
+func WithClusterMode(clusterMode bool) Option {
+	return func(b *RedisBreaker) { b.clusterMode = clusterMode }
+}
+
+// NewRedisBreaker builds a RedisBreaker on top of client.
+func NewRedisBreaker(client redis.UniversalClient, opts ...Option) *RedisBreaker {
+	b := &RedisBreaker{
+		client:    client,
+		threshold: 3,
+		cooldown:  30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+func key(provider string) string {
+	return fmt.Sprintf("cb:%s", provider)
+}
+
+// allowScript implements the closed/open/half-open decision and the
+// half-open probe CAS in one round trip: KEYS[1] is the provider hash,
+// ARGV[1] is the cooldown in seconds, ARGV[2] is now (unix seconds).
+// Returns {allowed (0/1), probe (0/1)}.
+var allowScript = redis.NewScript(`
+local state = redis.call('HGET', KEYS[1], 'state')
+local now = tonumber(ARGV[2])
+
+if state == false or state == 'closed' then
+	return {1, 0}
+end
+
+if state == 'open' then
+	local last_failure = tonumber(redis.call('HGET', KEYS[1], 'last_failure')) or 0
+	if now - last_failure < tonumber(ARGV[1]) then
+		return {0, 0}
+	end
+	-- cooldown elapsed: move to half-open and hand out the single probe token
+	redis.call('HSET', KEYS[1], 'state', 'half-open', 'half_open_token', '0')
+	return {1, 1}
+end
+
+if state == 'half-open' then
+	local token = redis.call('HGET', KEYS[1], 'half_open_token')
+	if token == '1' then
+		redis.call('HSET', KEYS[1], 'half_open_token', '0')
+		return {1, 1}
+	end
+	return {0, 0}
+end
+
+return {0, 0}
+`)
+
+// recordFailureScript increments the failure count and opens the circuit
+// once ARGV[1] (threshold) is reached. It only touches KEYS[1], the
+// provider hash — Redis Cluster hashes a multi-key EVAL's keys to a slot
+// each, so pairing this with the circuit_events key (a different name,
+// a different slot) would CROSSSLOT-fail on a real cluster. The caller
+// publishes circuit_events itself once this returns, using the
+// transitioned flag to know whether a state change actually happened.
+// KEYS[1] is the provider hash. ARGV[1] threshold, ARGV[2] now.
+// Returns {failures, transitioned (0/1)}.
+var recordFailureScript = redis.NewScript(`
+local failures = redis.call('HINCRBY', KEYS[1], 'failures', 1)
+redis.call('HSET', KEYS[1], 'last_failure', ARGV[2])
+
+local state = redis.call('HGET', KEYS[1], 'state')
+local transitioned = 0
+if failures >= tonumber(ARGV[1]) and state ~= 'open' then
+	redis.call('HSET', KEYS[1], 'state', 'open', 'half_open_token', '0')
+	transitioned = 1
+elseif state == 'half-open' then
+	-- failed probe: re-open immediately without waiting for more failures
+	redis.call('HSET', KEYS[1], 'state', 'open', 'half_open_token', '0')
+	transitioned = 1
+end
+return {failures, transitioned}
+`)
+
+// recordSuccessScript closes the circuit and resets failure tracking. Like
+// recordFailureScript, it only touches KEYS[1] so it stays single-slot on
+// Redis Cluster; the caller publishes circuit_events itself.
+// KEYS[1] is the provider hash. Returns {transitioned (0/1)}.
+var recordSuccessScript = redis.NewScript(`
+local state = redis.call('HGET', KEYS[1], 'state')
+redis.call('HSET', KEYS[1], 'state', 'closed', 'failures', 0, 'half_open_token', '0')
+local transitioned = 0
+if state ~= false and state ~= 'closed' then
+	transitioned = 1
+end
+return {transitioned}
+`)
+
+func (b *RedisBreaker) Allow(ctx context.Context, provider string) (allowed bool, probe bool, err error) {
+	res, err := allowScript.Run(ctx, b.client, []string{key(provider)},
+		int64(b.cooldown.Seconds()), time.Now().Unix()).Slice()
+	if err != nil {
+		return false, false, fmt.Errorf("circuitbreaker: allow %s: %w", provider, err)
+	}
+	if len(res) != 2 {
+		return false, false, fmt.Errorf("circuitbreaker: unexpected allow result %v", res)
+	}
+	return toInt64(res[0]) == 1, toInt64(res[1]) == 1, nil
+}
+
+func (b *RedisBreaker) RecordFailure(ctx context.Context, provider string) error {
+	now := time.Now().Unix()
+	res, err := recordFailureScript.Run(ctx, b.client, []string{key(provider)},
+		b.threshold, now).Slice()
+	if err != nil {
+		return fmt.Errorf("circuitbreaker: record failure for %s: %w", provider, err)
+	}
+	if len(res) != 2 {
+		return fmt.Errorf("circuitbreaker: unexpected record-failure result %v", res)
+	}
+	if toInt64(res[1]) == 1 {
+		b.publish(ctx, provider, StateOpen, toInt64(res[0]), now)
+	}
+	return nil
+}
+
+func (b *RedisBreaker) RecordSuccess(ctx context.Context, provider string) error {
+	now := time.Now().Unix()
+	res, err := recordSuccessScript.Run(ctx, b.client, []string{key(provider)}, now).Slice()
+	if err != nil {
+		return fmt.Errorf("circuitbreaker: record success for %s: %w", provider, err)
+	}
+	if len(res) != 1 {
+		return fmt.Errorf("circuitbreaker: unexpected record-success result %v", res)
+	}
+	if toInt64(res[0]) == 1 {
+		b.publish(ctx, provider, StateClosed, 0, now)
+	}
+	return nil
+}
+
+// publish emits a circuit_events message as its own Redis command, outside
+// of the CAS script, so the event fan-out never shares an EVAL with the
+// per-provider key (see recordFailureScript). It uses SPUBLISH in cluster
+// mode so it lands on the shard the health-checker's SSUBSCRIBE is
+// listening on (see WithClusterMode).
+func (b *RedisBreaker) publish(ctx context.Context, provider string, state State, failures, at int64) {
+	payload, err := json.Marshal(Event{Provider: provider, State: state, Failures: failures, At: at})
+	if err != nil {
+		slog.Default().Error("circuitbreaker: encode event", "provider", provider, "error", err)
+		return
+	}
+	var pubErr error
+	if b.clusterMode {
+		pubErr = b.client.SPublish(ctx, EventsChannel, payload).Err()
+	} else {
+		pubErr = b.client.Publish(ctx, EventsChannel, payload).Err()
+	}
+	if pubErr != nil {
+		slog.Default().Error("circuitbreaker: publish event", "provider", provider, "error", pubErr)
+	}
+}
+
+// State returns the provider's current state, defaulting to StateClosed
+// if nothing has been recorded for it yet.
+func (b *RedisBreaker) State(ctx context.Context, provider string) (State, error) {
+	val, err := b.client.HGet(ctx, key(provider), "state").Result()
+	if err == redis.Nil {
+		return StateClosed, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("circuitbreaker: state for %s: %w", provider, err)
+	}
+	return State(val), nil
+}
+
+// DecodeEvent unmarshals a circuit_events Pub/Sub payload.
+func DecodeEvent(payload string) (Event, error) {
+	var e Event
+	if err := json.Unmarshal([]byte(payload), &e); err != nil {
+		return Event{}, fmt.Errorf("circuitbreaker: decode event: %w", err)
+	}
+	return e, nil
+}
+
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,117 @@
+package circuitbreaker
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestKeyNamespacesByProvider(t *testing.T) {
+	if got, want := key("openweathermap"), "cb:openweathermap"; got != want {
+		t.Errorf("key() = %q, want %q", got, want)
+	}
+}
+
+func TestToInt64(t *testing.T) {
+	if got := toInt64(int64(7)); got != 7 {
+		t.Errorf("toInt64(int64) = %d, want 7", got)
+	}
+	if got := toInt64("not a number"); got != 0 {
+		t.Errorf("toInt64(string) = %d, want 0", got)
+	}
+	if got := toInt64(nil); got != 0 {
+		t.Errorf("toInt64(nil) = %d, want 0", got)
+	}
+}
+
+func TestDecodeEvent(t *testing.T) {
+	event, err := DecodeEvent(`{"provider":"openweathermap","state":"open","failures":3,"at":1700000000}`)
+	if err != nil {
+		t.Fatalf("DecodeEvent() error = %v", err)
+	}
+	want := Event{Provider: "openweathermap", State: StateOpen, Failures: 3, At: 1700000000}
+	if event != want {
+		t.Errorf("DecodeEvent() = %+v, want %+v", event, want)
+	}
+}
+
+func TestDecodeEventInvalidJSON(t *testing.T) {
+	if _, err := DecodeEvent("not json"); err == nil {
+		t.Error("DecodeEvent() error = nil, want non-nil")
+	}
+}
+
+// newTestClient connects to REDIS_ADDR for the Lua-script CAS tests below,
+// which need a real Redis to exercise the scripts themselves. Skipped when
+// REDIS_ADDR isn't set (e.g. this repo's default sandboxed CI).
+func newTestClient(t *testing.T) redis.UniversalClient {
+	t.Helper()
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR not set, skipping Redis integration test")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("could not reach Redis at %s: %v", addr, err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestRedisBreakerOpensAfterThreshold(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	provider := "it-threshold"
+	t.Cleanup(func() { client.Del(ctx, key(provider)) })
+
+	b := NewRedisBreaker(client, WithThreshold(2))
+
+	if err := b.RecordFailure(ctx, provider); err != nil {
+		t.Fatalf("RecordFailure() error = %v", err)
+	}
+	if state, err := b.State(ctx, provider); err != nil || state != StateClosed {
+		t.Fatalf("state after 1 failure = %v, %v, want %v, nil", state, err, StateClosed)
+	}
+
+	if err := b.RecordFailure(ctx, provider); err != nil {
+		t.Fatalf("RecordFailure() error = %v", err)
+	}
+	if state, err := b.State(ctx, provider); err != nil || state != StateOpen {
+		t.Fatalf("state after threshold failures = %v, %v, want %v, nil", state, err, StateOpen)
+	}
+}
+
+func TestRedisBreakerHalfOpenGrantsSingleProbe(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	provider := "it-half-open"
+	t.Cleanup(func() { client.Del(ctx, key(provider)) })
+
+	b := NewRedisBreaker(client, WithThreshold(1), WithCooldown(0))
+
+	if err := b.RecordFailure(ctx, provider); err != nil {
+		t.Fatalf("RecordFailure() error = %v", err)
+	}
+
+	allowed, probe, err := b.Allow(ctx, provider)
+	if err != nil || !allowed || !probe {
+		t.Fatalf("first Allow() after cooldown = %v, %v, %v, want true, true, nil", allowed, probe, err)
+	}
+
+	allowed, probe, err = b.Allow(ctx, provider)
+	if err != nil || allowed || probe {
+		t.Fatalf("second Allow() while probe in flight = %v, %v, %v, want false, false, nil", allowed, probe, err)
+	}
+
+	if err := b.RecordSuccess(ctx, provider); err != nil {
+		t.Fatalf("RecordSuccess() error = %v", err)
+	}
+	if state, err := b.State(ctx, provider); err != nil || state != StateClosed {
+		t.Fatalf("state after successful probe = %v, %v, want %v, nil", state, err, StateClosed)
+	}
+}
@@ -3,25 +3,57 @@ package main
 import (
 	"context"  // for managing background tasks (used with Redis)
 	"fmt"      // for formatted printing
-	"log"      // for logging info and errors
+	"log/slog" // structured logging
 	"net/http" // to make HTTP requests (used for health check)
+	"os"       // for reading REDIS_MODE
+	"strings"  // for comparing REDIS_MODE case-insensitively
 	"time"     // to add timeouts, delays, etc.
 
 	"github.com/redis/go-redis/v9" // Redis client library
+
+	"github.com/DwaipayanSom/Resilient-API-Proxy-System/alerts"         // real alert backends (Slack, PagerDuty, webhook)
+	"github.com/DwaipayanSom/Resilient-API-Proxy-System/circuitbreaker" // decodes circuit_events payloads
+	"github.com/DwaipayanSom/Resilient-API-Proxy-System/logging"        // structured JSON logger
+	"github.com/DwaipayanSom/Resilient-API-Proxy-System/metrics"        // Prometheus collectors
+	"github.com/DwaipayanSom/Resilient-API-Proxy-System/redisconn"      // builds the Redis client from REDIS_MODE and friends
 )
 
 // Declare global variables
-var redisClient *redis.Client  // Redis client instance
-var ctx = context.Background() // context for Redis operations
+var redisClient redis.UniversalClient // Redis client instance
+var ctx = context.Background()        // context for Redis operations
+var clusterMode bool                  // true when REDIS_MODE=cluster, so pub/sub must use the sharded commands
+var logger *slog.Logger               // structured JSON logger, level set by LOG_LEVEL
+var hcMetrics *metrics.HealthCheck    // Prometheus collectors
+var alertSink alerts.Sink             // debounced fan-out to whichever backends are configured
 
 func main() {
-	// Connect to the Redis service running in Docker
-	redisClient = redis.NewClient(&redis.Options{
-		Addr: "redis:6379", // Redis hostname inside Docker Compose network
-	})
+	logger = logging.New("heartbeat-service")
+	hcMetrics = metrics.NewHealthCheck()
+	alertSink = alerts.NewFromEnv()
+
+	// Connect to Redis according to REDIS_MODE (single, sentinel, or cluster)
+	var err error
+	redisClient, err = redisconn.NewUniversalClient(ctx)
+	if err != nil {
+		logger.Error("failed to connect to Redis", "error", err)
+		os.Exit(1)
+	}
+	clusterMode = strings.EqualFold(os.Getenv("REDIS_MODE"), "cluster")
 
 	// Start listening to status messages published on Redis in a new goroutine
 	go subscribeToStatus()
+	go subscribeToCircuitEvents()
+
+	// Serve /metrics on its own port so health-check polling below never
+	// blocks it.
+	http.Handle("/metrics", metrics.Handler())
+	go func() {
+		addr := ":9090"
+		logger.Info("heartbeat-service metrics server running", "addr", addr)
+		if err := http.ListenAndServe(addr, nil); err != nil {
+			logger.Error("metrics server exited", "error", err)
+		}
+	}()
 
 	// Continuously check the health of API Proxy every 5 seconds
 	for {
@@ -37,42 +69,82 @@ func checkHealth() {
 		Timeout: 5 * time.Second,
 	}
 
+	start := time.Now()
 	// Attempt to call the health endpoint of the api-proxy service
 	resp, err := client.Get("http://api-proxy:8080/health") // Use Docker Compose service name
+	hcMetrics.ProbeDuration.Observe(time.Since(start).Seconds())
 	if err != nil {
 		// If there's a network error (e.g., container not reachable)
-		log.Println("❌ API Proxy is unreachable:", err)
-		mockSlackAlert("API Proxy failed health check!") // send mock alert
+		logger.Error("API Proxy is unreachable", "error", err)
+		hcMetrics.TargetUp.Set(0)
+		sendAlert("unreachable", "API Proxy failed health check!")
 		return
 	}
 	defer resp.Body.Close() // close the response body when done
 
 	// If the response doesn't return HTTP 200 OK
 	if resp.StatusCode != 200 {
-		log.Println("❌ API Proxy unhealthy status code:", resp.StatusCode)
-		mockSlackAlert("API Proxy returned non-200 from /health!") // send mock alert
+		logger.Error("API Proxy unhealthy status code", "status_code", resp.StatusCode)
+		hcMetrics.TargetUp.Set(0)
+		sendAlert("unhealthy_status", fmt.Sprintf("API Proxy returned non-200 from /health! (status %d)", resp.StatusCode))
 		return
 	}
 
 	// If everything is fine
-	log.Println("✅ API Proxy is healthy")
+	logger.Info("API Proxy is healthy")
+	hcMetrics.TargetUp.Set(1)
 }
 
 // Listens for messages published on the Redis channel and prints them
 func subscribeToStatus() {
-	// Subscribe to the "status_channel" in Redis
-	sub := redisClient.Subscribe(ctx, "status_channel")
+	// Subscribe to the "status_channel" in Redis. In cluster mode this must be
+	// a shard subscription (SSUBSCRIBE) to reach the node that owns the channel.
+	var sub *redis.PubSub
+	if clusterMode {
+		sub = redisClient.SSubscribe(ctx, "status_channel")
+	} else {
+		sub = redisClient.Subscribe(ctx, "status_channel")
+	}
 
 	// Get the channel that receives published messages
 	ch := sub.Channel()
 
 	// Loop over incoming messages
 	for msg := range ch {
-		log.Println("📡 Status from API Proxy:", msg.Payload) // print message content to console
+		logger.Info("status from API Proxy", "payload", msg.Payload) // log message content
+	}
+}
+
+// Listens for circuit breaker state changes published by the api-proxy and
+// alerts whenever a provider's circuit opens.
+func subscribeToCircuitEvents() {
+	var sub *redis.PubSub
+	if clusterMode {
+		sub = redisClient.SSubscribe(ctx, circuitbreaker.EventsChannel)
+	} else {
+		sub = redisClient.Subscribe(ctx, circuitbreaker.EventsChannel)
+	}
+
+	ch := sub.Channel()
+	for msg := range ch {
+		event, err := circuitbreaker.DecodeEvent(msg.Payload)
+		if err != nil {
+			logger.Error("error decoding circuit event", "error", err)
+			continue
+		}
+		logger.Info("circuit state changed", "provider", event.Provider, "state", event.State, "failures", event.Failures)
+		if event.State == circuitbreaker.StateOpen {
+			sendAlert("circuit_open:"+event.Provider, fmt.Sprintf("Circuit opened for provider %s after %d failures", event.Provider, event.Failures))
+		}
 	}
 }
 
-// Simulates sending an alert to Slack (just prints to terminal)
-func mockSlackAlert(msg string) {
-	fmt.Println("🚨 MOCK SLACK ALERT:", msg)
+// sendAlert delivers msg to the configured alert sink(s), tagging it with
+// reason so repeated alerts of the same kind debounce against each other
+// instead of paging once per failure.
+func sendAlert(reason, msg string) {
+	alert := alerts.Alert{Service: "heartbeat-service", Reason: reason, Message: msg}
+	if err := alertSink.Send(ctx, alert); err != nil {
+		logger.Error("failed to send alert", "reason", reason, "error", err)
+	}
 }
@@ -0,0 +1,60 @@
+// Package logging builds the structured JSON loggers used by both
+// binaries, so request id, provider, city, latency, and circuit state
+// show up as queryable fields instead of being buried in a formatted
+// string. The verbosity of both binaries is controlled by one LOG_LEVEL
+// env var (debug, info, warn, error; default info).
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// New builds a JSON slog.Logger tagged with service, honoring LOG_LEVEL.
+func New(service string) *slog.Logger {
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: levelFromEnv()})
+	return slog.New(handler).With("service", service)
+}
+
+func levelFromEnv() slog.Level {
+	switch strings.ToLower(os.Getenv("LOG_LEVEL")) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+type contextKey int
+
+const requestIDKey contextKey = iota
+
+// NewRequestID generates a short random id for correlating the log lines
+// of a single request.
+func NewRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID attaches id to ctx for later retrieval by RequestID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the request id stashed in ctx by WithRequestID, or
+// "" if none was set.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
@@ -0,0 +1,75 @@
+// Package metrics defines the Prometheus collectors for both binaries
+// and exposes the /metrics handler they serve them on.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Circuit breaker gauge values for apiproxy_circuit_state.
+const (
+	CircuitClosed   = 0
+	CircuitHalfOpen = 1
+	CircuitOpen     = 2
+)
+
+// APIProxy holds the api-proxy's Prometheus collectors.
+type APIProxy struct {
+	RequestsTotal    *prometheus.CounterVec
+	RequestDuration  *prometheus.HistogramVec
+	CircuitState     *prometheus.GaugeVec
+	ProviderFailures *prometheus.CounterVec
+}
+
+// NewAPIProxy registers and returns the api-proxy's collectors.
+func NewAPIProxy() *APIProxy {
+	return &APIProxy{
+		RequestsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "apiproxy_requests_total",
+			Help: "Total /weather requests, by provider and outcome status.",
+		}, []string{"provider", "status"}),
+		RequestDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "apiproxy_request_duration_seconds",
+			Help:    "Latency of successful /weather requests, cache hits included, by the provider whose data was served.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"provider"}),
+		CircuitState: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "apiproxy_circuit_state",
+			Help: "Circuit breaker state per provider: 0=closed, 1=half-open, 2=open.",
+		}, []string{"provider"}),
+		ProviderFailures: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "apiproxy_provider_failures_total",
+			Help: "Total provider fetch failures, by provider.",
+		}, []string{"provider"}),
+	}
+}
+
+// HealthCheck holds the health-checker's Prometheus collectors.
+type HealthCheck struct {
+	ProbeDuration prometheus.Histogram
+	TargetUp      prometheus.Gauge
+}
+
+// NewHealthCheck registers and returns the health-checker's collectors.
+func NewHealthCheck() *HealthCheck {
+	return &HealthCheck{
+		ProbeDuration: promauto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "healthcheck_probe_duration_seconds",
+			Help:    "Latency of health probes against the api-proxy.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		TargetUp: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "healthcheck_target_up",
+			Help: "1 if the last health probe against the api-proxy succeeded, else 0.",
+		}),
+	}
+}
+
+// Handler serves the registered collectors in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,97 @@
+package providers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the top-level shape of a providers config file (YAML or JSON).
+type Config struct {
+	Providers []ProviderConfig `yaml:"providers" json:"providers"`
+}
+
+// ProviderConfig describes one provider adapter: where to call it, how to
+// authenticate, and how to map its response onto NormalizedWeather.
+type ProviderConfig struct {
+	Name             string           `yaml:"name" json:"name"`
+	EndpointTemplate string           `yaml:"endpoint_template" json:"endpoint_template"`
+	AuthEnvVar       string           `yaml:"auth_env_var" json:"auth_env_var"`
+	TimeoutMS        int              `yaml:"timeout_ms" json:"timeout_ms"`
+	Weight           int              `yaml:"weight" json:"weight"`
+	Normalize        NormalizeMapping `yaml:"normalize" json:"normalize"`
+}
+
+// NormalizeMapping locates fields in a provider's decoded JSON body using
+// dot-separated paths (array elements are indexed numerically, e.g.
+// "weather.0.main").
+type NormalizeMapping struct {
+	TemperatureCPath string `yaml:"temperature_c_path" json:"temperature_c_path"`
+	ConditionPath    string `yaml:"condition_path" json:"condition_path"`
+}
+
+// LoadConfig reads a providers config file, dispatching on its extension.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("providers: read config %s: %w", path, err)
+	}
+
+	var cfg Config
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	case ".json":
+		err = yaml.Unmarshal(data, &cfg) // JSON is a valid subset of YAML
+	default:
+		return nil, fmt.Errorf("providers: unsupported config extension %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("providers: parse config %s: %w", path, err)
+	}
+	if len(cfg.Providers) == 0 {
+		return nil, fmt.Errorf("providers: config %s defines no providers", path)
+	}
+	return &cfg, nil
+}
+
+// NewRegistry builds a Registry of TemplateProvider adapters from cfg,
+// ordered from highest to lowest weight.
+func NewRegistry(cfg *Config) (*Registry, error) {
+	adapters := make([]Provider, 0, len(cfg.Providers))
+	for _, pc := range cfg.Providers {
+		if pc.Name == "" || pc.EndpointTemplate == "" {
+			return nil, fmt.Errorf("providers: provider entry missing name or endpoint_template: %+v", pc)
+		}
+		timeout := time.Duration(pc.TimeoutMS) * time.Millisecond
+		if timeout <= 0 {
+			timeout = 5 * time.Second
+		}
+		adapters = append(adapters, &TemplateProvider{
+			name:             pc.Name,
+			endpointTemplate: pc.EndpointTemplate,
+			authEnvVar:       pc.AuthEnvVar,
+			timeout:          timeout,
+			weight:           pc.Weight,
+			normalize:        pc.Normalize,
+		})
+	}
+
+	sort.SliceStable(adapters, func(i, j int) bool {
+		return weightOf(adapters[i]) > weightOf(adapters[j])
+	})
+
+	return &Registry{providers: adapters}, nil
+}
+
+func weightOf(p Provider) int {
+	if tp, ok := p.(*TemplateProvider); ok {
+		return tp.weight
+	}
+	return 0
+}
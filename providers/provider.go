@@ -0,0 +1,30 @@
+// Package providers turns the weather backends the api-proxy talks to
+// into pluggable, config-driven adapters instead of URLs hardcoded in
+// the handler. A Registry loaded from a YAML or JSON file describes each
+// provider's endpoint, auth, timeout and weight, and knows how to map
+// its JSON response onto a common NormalizedWeather shape.
+package providers
+
+import (
+	"context"
+	"time"
+)
+
+// NormalizedWeather is the common shape every provider adapter maps its
+// response onto, so the handler never has to special-case a provider.
+type NormalizedWeather struct {
+	City         string    `json:"city"`
+	TemperatureC float64   `json:"temperature_c"`
+	Condition    string    `json:"condition"`
+	Provider     string    `json:"provider"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// Provider is a single weather backend.
+type Provider interface {
+	// Name is the provider identifier used for config, circuit breaker
+	// state, caching keys, and metrics labels.
+	Name() string
+	// Fetch retrieves and normalizes weather data for city.
+	Fetch(ctx context.Context, city string) (NormalizedWeather, error)
+}
@@ -0,0 +1,157 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CircuitGate is the subset of circuitbreaker.Breaker the registry needs
+// to skip disabled providers and report outcomes. Defined locally so
+// this package doesn't depend on circuitbreaker — any breaker
+// implementation with these methods works.
+//
+// Allow and Reserve are deliberately separate: Allow is used to build the
+// candidate list and must be side-effect-free with respect to quotas,
+// since every configured provider is considered on every request but only
+// some are ever launched. Reserve is called once per provider, right
+// before it's actually fetched, so quota (e.g. a per-provider rate limit)
+// is only spent on requests that really go out.
+type CircuitGate interface {
+	Allow(ctx context.Context, provider string) (allowed bool, probe bool, err error)
+	Reserve(ctx context.Context, provider string) (allowed bool, err error)
+	RecordSuccess(ctx context.Context, provider string) error
+	RecordFailure(ctx context.Context, provider string) error
+}
+
+// Registry holds the configured providers in descending weight order.
+type Registry struct {
+	providers []Provider
+}
+
+// Providers returns the registry's providers, highest weight first.
+func (r *Registry) Providers() []Provider {
+	return r.providers
+}
+
+type fetchResult struct {
+	provider string
+	weather  NormalizedWeather
+	err      error
+}
+
+// FetchHedged tries providers in weight order, skipping any gate denies.
+// If the highest-weight allowed provider hasn't answered within
+// hedgeDelay, the next-highest-weight allowed provider is fired in
+// parallel; whichever responds successfully first wins and the other is
+// cancelled. Every attempt that finishes before a winner is found is
+// reported back to gate; attempts cancelled because another provider won
+// are not reported.
+func (r *Registry) FetchHedged(ctx context.Context, city string, gate CircuitGate, hedgeDelay time.Duration) (NormalizedWeather, error) {
+	candidates := r.allowed(ctx, gate)
+	if len(candidates) == 0 {
+		return NormalizedWeather{}, fmt.Errorf("providers: no providers available")
+	}
+
+	results := make(chan fetchResult, len(candidates))
+	var wg sync.WaitGroup
+	var cancels []context.CancelFunc
+
+	cancelAll := func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+
+	// launchNext walks forward from next, reserving quota for and starting
+	// the first candidate that isn't currently rate-limited. It returns the
+	// index to resume from next time, whether or not it found one to start.
+	launchNext := func(next int) int {
+		for ; next < len(candidates); next++ {
+			cancel, err := r.launch(ctx, gate, candidates[next], city, results, &wg)
+			if err != nil {
+				continue
+			}
+			cancels = append(cancels, cancel)
+			return next + 1
+		}
+		return next
+	}
+
+	next := launchNext(0)
+	inFlight := len(cancels)
+	if inFlight == 0 {
+		return NormalizedWeather{}, fmt.Errorf("providers: no providers available")
+	}
+
+	timer := time.NewTimer(hedgeDelay)
+	defer timer.Stop()
+
+	var lastErr error
+	for inFlight > 0 {
+		select {
+		case res := <-results:
+			inFlight--
+			if res.err == nil {
+				_ = gate.RecordSuccess(ctx, res.provider)
+				cancelAll()
+				wg.Wait()
+				return res.weather, nil
+			}
+			_ = gate.RecordFailure(ctx, res.provider)
+			lastErr = res.err
+			before := len(cancels)
+			next = launchNext(next)
+			if len(cancels) > before {
+				inFlight++
+			}
+
+		case <-timer.C:
+			before := len(cancels)
+			next = launchNext(next)
+			if len(cancels) > before {
+				inFlight++
+			}
+		}
+	}
+
+	cancelAll()
+	wg.Wait()
+	return NormalizedWeather{}, fmt.Errorf("providers: all providers failed: %w", lastErr)
+}
+
+// launch reserves quota for p via gate.Reserve and, if granted, starts
+// fetching it in the background, sending its outcome on results. It
+// returns a non-nil error when p is currently rate-limited, so the
+// caller can try the next candidate instead of spending a hedge slot on
+// a request that was never going to go out.
+func (r *Registry) launch(ctx context.Context, gate CircuitGate, p Provider, city string, results chan<- fetchResult, wg *sync.WaitGroup) (context.CancelFunc, error) {
+	allowed, err := gate.Reserve(ctx, p.Name())
+	if err != nil || !allowed {
+		return nil, fmt.Errorf("providers: %s not reserved", p.Name())
+	}
+
+	pctx, cancel := context.WithCancel(ctx)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		weather, err := p.Fetch(pctx, city)
+		results <- fetchResult{provider: p.Name(), weather: weather, err: err}
+	}()
+	return cancel, nil
+}
+
+// allowed returns the registry's providers, in weight order, filtered to
+// those the circuit gate currently permits.
+func (r *Registry) allowed(ctx context.Context, gate CircuitGate) []Provider {
+	out := make([]Provider, 0, len(r.providers))
+	for _, p := range r.providers {
+		ok, _, err := gate.Allow(ctx, p.Name())
+		if err != nil || !ok {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
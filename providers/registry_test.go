@@ -0,0 +1,192 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeProvider answers Fetch after delay, unless its context is cancelled
+// first, in which case it records the cancellation and returns ctx.Err().
+type fakeProvider struct {
+	name      string
+	delay     time.Duration
+	result    NormalizedWeather
+	err       error
+	mu        sync.Mutex
+	cancelled bool
+}
+
+func (p *fakeProvider) Name() string { return p.name }
+
+func (p *fakeProvider) Fetch(ctx context.Context, city string) (NormalizedWeather, error) {
+	select {
+	case <-time.After(p.delay):
+		return p.result, p.err
+	case <-ctx.Done():
+		p.mu.Lock()
+		p.cancelled = true
+		p.mu.Unlock()
+		return NormalizedWeather{}, ctx.Err()
+	}
+}
+
+func (p *fakeProvider) wasCancelled() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cancelled
+}
+
+// fakeGate is a CircuitGate whose Allow/Reserve decisions are
+// pre-configured per provider and which records every call it receives,
+// so tests can assert exactly which providers were reserved, succeeded,
+// or failed.
+type fakeGate struct {
+	mu           sync.Mutex
+	denyAllow    map[string]bool
+	denyReserve  map[string]bool
+	allowCalls   []string
+	reserveCalls []string
+	successCalls []string
+	failureCalls []string
+}
+
+func (g *fakeGate) Allow(ctx context.Context, provider string) (bool, bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.allowCalls = append(g.allowCalls, provider)
+	if g.denyAllow[provider] {
+		return false, false, nil
+	}
+	return true, false, nil
+}
+
+func (g *fakeGate) Reserve(ctx context.Context, provider string) (bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.reserveCalls = append(g.reserveCalls, provider)
+	if g.denyReserve[provider] {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (g *fakeGate) RecordSuccess(ctx context.Context, provider string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.successCalls = append(g.successCalls, provider)
+	return nil
+}
+
+func (g *fakeGate) RecordFailure(ctx context.Context, provider string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.failureCalls = append(g.failureCalls, provider)
+	return nil
+}
+
+func (g *fakeGate) calledWith(calls []string, provider string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, c := range calls {
+		if c == provider {
+			return true
+		}
+	}
+	return false
+}
+
+func newFakeGate() *fakeGate {
+	return &fakeGate{denyAllow: map[string]bool{}, denyReserve: map[string]bool{}}
+}
+
+func TestFetchHedgedFiresSecondProviderAfterDelay(t *testing.T) {
+	slow := &fakeProvider{name: "slow", delay: 100 * time.Millisecond, result: NormalizedWeather{Provider: "slow"}}
+	fast := &fakeProvider{name: "fast", delay: 10 * time.Millisecond, result: NormalizedWeather{Provider: "fast"}}
+	r := &Registry{providers: []Provider{slow, fast}}
+	gate := newFakeGate()
+
+	weather, err := r.FetchHedged(context.Background(), "London", gate, 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("FetchHedged() error = %v", err)
+	}
+	if weather.Provider != "fast" {
+		t.Fatalf("FetchHedged() winner = %q, want %q", weather.Provider, "fast")
+	}
+	if !gate.calledWith(gate.reserveCalls, "fast") {
+		t.Errorf("Reserve was never called for the hedged provider %q", "fast")
+	}
+	if !slow.wasCancelled() {
+		t.Error("the slower loser provider should have been cancelled once the hedge won")
+	}
+}
+
+func TestFetchHedgedOnlyReservesLaunchedProviders(t *testing.T) {
+	fast := &fakeProvider{name: "fast", delay: 5 * time.Millisecond, result: NormalizedWeather{Provider: "fast"}}
+	neverLaunched := &fakeProvider{name: "never-launched", delay: time.Second, result: NormalizedWeather{Provider: "never-launched"}}
+	r := &Registry{providers: []Provider{fast, neverLaunched}}
+	gate := newFakeGate()
+
+	weather, err := r.FetchHedged(context.Background(), "Paris", gate, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("FetchHedged() error = %v", err)
+	}
+	if weather.Provider != "fast" {
+		t.Fatalf("FetchHedged() winner = %q, want %q", weather.Provider, "fast")
+	}
+
+	if !gate.calledWith(gate.reserveCalls, "fast") {
+		t.Error("Reserve should have been called for the launched provider")
+	}
+	if gate.calledWith(gate.reserveCalls, "never-launched") {
+		t.Error("Reserve should not be called for a provider that was never launched")
+	}
+	// Allow, on the other hand, builds the candidate list and is expected
+	// to consider every configured provider.
+	if !gate.calledWith(gate.allowCalls, "never-launched") {
+		t.Error("Allow should still be called while building the candidate list")
+	}
+}
+
+func TestFetchHedgedReturnsErrorWhenAllCandidatesDenied(t *testing.T) {
+	p := &fakeProvider{name: "only", delay: time.Millisecond}
+	r := &Registry{providers: []Provider{p}}
+	gate := newFakeGate()
+	gate.denyAllow["only"] = true
+
+	_, err := r.FetchHedged(context.Background(), "Berlin", gate, 10*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "no providers available") {
+		t.Fatalf("FetchHedged() error = %v, want it to mention %q", err, "no providers available")
+	}
+}
+
+func TestFetchHedgedReturnsErrorWhenAllCandidatesFail(t *testing.T) {
+	boom := errors.New("boom")
+	a := &fakeProvider{name: "a", delay: time.Millisecond, err: boom}
+	b := &fakeProvider{name: "b", delay: time.Millisecond, err: boom}
+	r := &Registry{providers: []Provider{a, b}}
+	gate := newFakeGate()
+
+	_, err := r.FetchHedged(context.Background(), "Tokyo", gate, time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "all providers failed") {
+		t.Fatalf("FetchHedged() error = %v, want it to mention %q", err, "all providers failed")
+	}
+	if !gate.calledWith(gate.failureCalls, "a") || !gate.calledWith(gate.failureCalls, "b") {
+		t.Errorf("RecordFailure calls = %v, want both a and b", gate.failureCalls)
+	}
+}
+
+func TestFetchHedgedNoProvidersAvailableWhenAllRateLimited(t *testing.T) {
+	p := &fakeProvider{name: "limited", delay: time.Millisecond}
+	r := &Registry{providers: []Provider{p}}
+	gate := newFakeGate()
+	gate.denyReserve["limited"] = true
+
+	_, err := r.FetchHedged(context.Background(), "Rome", gate, 10*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "no providers available") {
+		t.Fatalf("FetchHedged() error = %v, want it to mention %q", err, "no providers available")
+	}
+}
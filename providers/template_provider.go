@@ -0,0 +1,132 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TemplateProvider is a config-driven Provider: it formats a URL from a
+// template, decodes the JSON response, and plucks the normalized fields
+// out using the dot-paths from its NormalizeMapping. This covers every
+// provider we talk to today without writing a bespoke adapter per API.
+type TemplateProvider struct {
+	name             string
+	endpointTemplate string
+	authEnvVar       string
+	timeout          time.Duration
+	weight           int
+	normalize        NormalizeMapping
+}
+
+func (p *TemplateProvider) Name() string { return p.name }
+
+func (p *TemplateProvider) Fetch(ctx context.Context, city string) (NormalizedWeather, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.buildURL(city), nil)
+	if err != nil {
+		return NormalizedWeather{}, fmt.Errorf("providers: %s: build request: %w", p.name, err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return NormalizedWeather{}, fmt.Errorf("providers: %s: request failed: %w", p.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return NormalizedWeather{}, fmt.Errorf("providers: %s: unexpected status %d", p.name, resp.StatusCode)
+	}
+
+	var body interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return NormalizedWeather{}, fmt.Errorf("providers: %s: decode response: %w", p.name, err)
+	}
+
+	temp, _ := lookupFloat(body, p.normalize.TemperatureCPath)
+	condition, _ := lookupString(body, p.normalize.ConditionPath)
+
+	return NormalizedWeather{
+		City:         city,
+		TemperatureC: temp,
+		Condition:    condition,
+		Provider:     p.name,
+		FetchedAt:    time.Now(),
+	}, nil
+}
+
+// buildURL substitutes {city} and {key} placeholders in the endpoint
+// template; {key} comes from the provider's configured auth env var.
+func (p *TemplateProvider) buildURL(city string) string {
+	key := ""
+	if p.authEnvVar != "" {
+		key = os.Getenv(p.authEnvVar)
+	}
+	out := strings.ReplaceAll(p.endpointTemplate, "{city}", url.QueryEscape(city))
+	out = strings.ReplaceAll(out, "{key}", url.QueryEscape(key))
+	return out
+}
+
+// lookupPath walks a dot-separated path through decoded JSON (maps and,
+// for numeric segments, slices), e.g. "weather.0.main".
+func lookupPath(data interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return nil, false
+	}
+	cur := data
+	for _, segment := range strings.Split(path, ".") {
+		switch node := cur.(type) {
+		case map[string]interface{}:
+			v, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			cur = v
+		case []interface{}:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil, false
+			}
+			cur = node[idx]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// lookupFloat extracts a numeric field. Some providers (e.g. wttr.in)
+// encode numbers as JSON strings, so a string that parses as a float is
+// accepted too.
+func lookupFloat(data interface{}, path string) (float64, bool) {
+	v, ok := lookupPath(data, path)
+	if !ok {
+		return 0, false
+	}
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func lookupString(data interface{}, path string) (string, bool) {
+	v, ok := lookupPath(data, path)
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
@@ -0,0 +1,37 @@
+package providers
+
+import "testing"
+
+func TestLookupPath(t *testing.T) {
+	data := map[string]interface{}{
+		"main": map[string]interface{}{"temp": 21.5},
+		"weather": []interface{}{
+			map[string]interface{}{"main": "Clouds"},
+		},
+	}
+
+	if temp, ok := lookupFloat(data, "main.temp"); !ok || temp != 21.5 {
+		t.Fatalf("lookupFloat(main.temp) = %v, %v; want 21.5, true", temp, ok)
+	}
+	if cond, ok := lookupString(data, "weather.0.main"); !ok || cond != "Clouds" {
+		t.Fatalf("lookupString(weather.0.main) = %q, %v; want Clouds, true", cond, ok)
+	}
+	if _, ok := lookupFloat(data, "main.missing"); ok {
+		t.Fatalf("lookupFloat(main.missing) should not be found")
+	}
+	if _, ok := lookupString(data, "weather.5.main"); ok {
+		t.Fatalf("lookupString with out-of-range index should not be found")
+	}
+}
+
+func TestLookupFloatFromString(t *testing.T) {
+	data := map[string]interface{}{
+		"current_condition": []interface{}{
+			map[string]interface{}{"temp_C": "18"},
+		},
+	}
+	temp, ok := lookupFloat(data, "current_condition.0.temp_C")
+	if !ok || temp != 18 {
+		t.Fatalf("lookupFloat(current_condition.0.temp_C) = %v, %v; want 18, true", temp, ok)
+	}
+}
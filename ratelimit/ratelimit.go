@@ -0,0 +1,135 @@
+// Package ratelimit implements a distributed token-bucket limiter backed
+// by Redis, so every api-proxy replica enforces the same quota instead of
+// each one tracking its own independent budget. A single Lua script does
+// the refill-then-decrement atomically, so concurrent requests from the
+// same client can't race their way past the limit.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Limiter is a named family of token buckets sharing one burst size and
+// refill rate, distinguished by key (e.g. a client IP or a provider name).
+type Limiter struct {
+	client     redis.UniversalClient
+	namespace  string
+	burst      int64
+	refillRate float64 // tokens added per second
+	ttl        time.Duration
+}
+
+// Option configures a Limiter.
+type Option func(*Limiter)
+
+// WithBurst sets the bucket capacity (max tokens). Default 10.
+func WithBurst(n int64) Option {
+	return func(l *Limiter) { l.burst = n }
+}
+
+// WithRefillRate sets how many tokens are added per second. Default 1.
+func WithRefillRate(tokensPerSecond float64) Option {
+	return func(l *Limiter) { l.refillRate = tokensPerSecond }
+}
+
+// New builds a Limiter. namespace scopes its Redis keys so operators can
+// run several independent policies (e.g. "client" and "provider") without
+// their buckets colliding.
+func New(client redis.UniversalClient, namespace string, opts ...Option) *Limiter {
+	l := &Limiter{
+		client:     client,
+		namespace:  namespace,
+		burst:      10,
+		refillRate: 1,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	// A bucket that's been idle for longer than it takes to refill from
+	// empty to full has nothing left to expire meaningfully; let Redis
+	// reclaim it instead of keeping every client's key forever.
+	l.ttl = time.Duration(float64(l.burst)/l.refillRate*float64(time.Second)) * 2
+	if l.ttl <= 0 {
+		l.ttl = time.Minute
+	}
+	return l
+}
+
+// Result is the outcome of a single Allow call.
+type Result struct {
+	Allowed   bool
+	Remaining int64
+	// WaitMS is how long the caller should wait before retrying, in
+	// milliseconds, when Allowed is false.
+	WaitMS int64
+}
+
+// takeScript implements refill-then-decrement atomically. KEYS[1] is the
+// bucket's hash key. ARGV: burst, refillRate (tokens/sec), now (unix
+// milliseconds), requested tokens, ttl (seconds).
+// Returns {allowed (0/1), remaining, wait_ms}.
+var takeScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local refill_rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local requested = tonumber(ARGV[4])
+local ttl = tonumber(ARGV[5])
+
+local stored = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(stored[1])
+local ts = tonumber(stored[2])
+
+if tokens == nil then
+	tokens = burst
+	ts = now
+end
+
+local elapsed_ms = math.max(0, now - ts)
+tokens = math.min(burst, tokens + (elapsed_ms / 1000.0) * refill_rate)
+
+local allowed = 0
+local wait_ms = 0
+if tokens >= requested then
+	tokens = tokens - requested
+	allowed = 1
+else
+	local deficit = requested - tokens
+	wait_ms = math.ceil((deficit / refill_rate) * 1000.0)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', now)
+redis.call('EXPIRE', KEYS[1], ttl)
+
+return {allowed, math.floor(tokens), wait_ms}
+`)
+
+// Allow attempts to take `cost` tokens from key's bucket.
+func (l *Limiter) Allow(ctx context.Context, key string, cost int64) (Result, error) {
+	bucketKey := fmt.Sprintf("ratelimit:%s:%s", l.namespace, key)
+	res, err := takeScript.Run(ctx, l.client, []string{bucketKey},
+		l.burst, l.refillRate, time.Now().UnixMilli(), cost, int64(l.ttl.Seconds())).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: allow %s/%s: %w", l.namespace, key, err)
+	}
+	if len(res) != 3 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected result %v", res)
+	}
+	return Result{
+		Allowed:   toInt64(res[0]) == 1,
+		Remaining: toInt64(res[1]),
+		WaitMS:    toInt64(res[2]),
+	}, nil
+}
+
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	default:
+		return 0
+	}
+}
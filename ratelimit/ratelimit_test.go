@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestNewAppliesDefaults(t *testing.T) {
+	l := New(nil, "test")
+	if l.burst != 10 {
+		t.Errorf("default burst = %d, want 10", l.burst)
+	}
+	if l.refillRate != 1 {
+		t.Errorf("default refillRate = %v, want 1", l.refillRate)
+	}
+}
+
+func TestWithBurstAndRefillRate(t *testing.T) {
+	l := New(nil, "test", WithBurst(50), WithRefillRate(2.5))
+	if l.burst != 50 {
+		t.Errorf("burst = %d, want 50", l.burst)
+	}
+	if l.refillRate != 2.5 {
+		t.Errorf("refillRate = %v, want 2.5", l.refillRate)
+	}
+}
+
+// newTestClient connects to REDIS_ADDR for the takeScript CAS tests below,
+// which need a real Redis to exercise the refill-then-decrement script.
+// Skipped when REDIS_ADDR isn't set (e.g. this repo's default sandboxed CI).
+func newTestClient(t *testing.T) redis.UniversalClient {
+	t.Helper()
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR not set, skipping Redis integration test")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("could not reach Redis at %s: %v", addr, err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestAllowDecrementsAndExhaustsBucket(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	bucketKey := "ratelimit:test:exhaust"
+	t.Cleanup(func() { client.Del(ctx, bucketKey) })
+
+	l := New(client, "test", WithBurst(2), WithRefillRate(1))
+
+	res, err := l.Allow(ctx, "exhaust", 1)
+	if err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	if !res.Allowed || res.Remaining != 1 {
+		t.Fatalf("first Allow() = %+v, want Allowed=true Remaining=1", res)
+	}
+
+	res, err = l.Allow(ctx, "exhaust", 1)
+	if err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	if !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("second Allow() = %+v, want Allowed=true Remaining=0", res)
+	}
+
+	res, err = l.Allow(ctx, "exhaust", 1)
+	if err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	if res.Allowed {
+		t.Fatalf("third Allow() = %+v, want Allowed=false once the bucket is empty", res)
+	}
+	if res.WaitMS <= 0 {
+		t.Errorf("third Allow() WaitMS = %d, want > 0", res.WaitMS)
+	}
+}
+
+func TestAllowRefillsOverTime(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	bucketKey := "ratelimit:test:refill"
+	t.Cleanup(func() { client.Del(ctx, bucketKey) })
+
+	l := New(client, "test", WithBurst(1), WithRefillRate(10))
+
+	if res, err := l.Allow(ctx, "refill", 1); err != nil || !res.Allowed {
+		t.Fatalf("first Allow() = %+v, %v, want Allowed=true", res, err)
+	}
+	if res, err := l.Allow(ctx, "refill", 1); err != nil || res.Allowed {
+		t.Fatalf("second Allow() = %+v, %v, want Allowed=false before refill", res, err)
+	}
+
+	time.Sleep(150 * time.Millisecond) // refill rate 10/s should add ~1.5 tokens
+
+	res, err := l.Allow(ctx, "refill", 1)
+	if err != nil {
+		t.Fatalf("Allow() after refill wait error = %v", err)
+	}
+	if !res.Allowed {
+		t.Errorf("Allow() after refill wait = %+v, want Allowed=true", res)
+	}
+}
@@ -0,0 +1,168 @@
+// Package redisconn builds a redis.UniversalClient from environment
+// variables so the api-proxy and health-checker can talk to a single
+// node, a Sentinel-managed failover group, or a Redis Cluster without
+// any code changes — only REDIS_MODE and its siblings differ between
+// deployments.
+package redisconn
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Mode selects which topology NewUniversalClient connects to.
+type Mode string
+
+const (
+	ModeSingle   Mode = "single"
+	ModeSentinel Mode = "sentinel"
+	ModeCluster  Mode = "cluster"
+)
+
+// sentinelDiscoveryRetries/Backoff bound how long we wait for a Sentinel
+// quorum to agree on a master before giving up on startup. go-redis
+// already retries dial failures against the sentinel addresses it knows
+// about, but the very first connection attempt can still race a
+// sentinel that hasn't finished its own failover bookkeeping yet.
+const (
+	sentinelDiscoveryRetries = 5
+	sentinelDiscoveryBackoff = 500 * time.Millisecond
+)
+
+// NewUniversalClient reads REDIS_MODE (default "single") and the
+// matching set of env vars, returning a ready-to-use
+// redis.UniversalClient. In sentinel mode it blocks briefly, retrying
+// with backoff, until a master has actually been discovered.
+func NewUniversalClient(ctx context.Context) (redis.UniversalClient, error) {
+	mode := Mode(strings.ToLower(os.Getenv("REDIS_MODE")))
+	if mode == "" {
+		mode = ModeSingle
+	}
+
+	tlsConfig, err := tlsConfigFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("redisconn: %w", err)
+	}
+
+	db, err := intEnv("REDIS_DB", 0)
+	if err != nil {
+		return nil, fmt.Errorf("redisconn: %w", err)
+	}
+
+	switch mode {
+	case ModeSingle:
+		addr := os.Getenv("REDIS_ADDR")
+		if addr == "" {
+			addr = "redis:6379"
+		}
+		return redis.NewClient(&redis.Options{
+			Addr:      addr,
+			Password:  os.Getenv("REDIS_PASSWORD"),
+			DB:        db,
+			TLSConfig: tlsConfig,
+		}), nil
+
+	case ModeSentinel:
+		addrs := splitAddrs(os.Getenv("REDIS_SENTINEL_ADDRS"))
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("redisconn: REDIS_SENTINEL_ADDRS is required in sentinel mode")
+		}
+		master := os.Getenv("REDIS_SENTINEL_MASTER")
+		if master == "" {
+			return nil, fmt.Errorf("redisconn: REDIS_SENTINEL_MASTER is required in sentinel mode")
+		}
+
+		client := redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       master,
+			SentinelAddrs:    addrs,
+			SentinelPassword: os.Getenv("REDIS_SENTINEL_PASSWORD"),
+			Password:         os.Getenv("REDIS_PASSWORD"),
+			DB:               db,
+			TLSConfig:        tlsConfig,
+		})
+
+		if err := waitForMaster(ctx, client); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("redisconn: sentinel master discovery failed: %w", err)
+		}
+		return client, nil
+
+	case ModeCluster:
+		addrs := splitAddrs(os.Getenv("REDIS_CLUSTER_ADDRS"))
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("redisconn: REDIS_CLUSTER_ADDRS is required in cluster mode")
+		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     addrs,
+			Password:  os.Getenv("REDIS_PASSWORD"),
+			TLSConfig: tlsConfig,
+		}), nil
+
+	default:
+		return nil, fmt.Errorf("redisconn: unknown REDIS_MODE %q (want single, sentinel, or cluster)", mode)
+	}
+}
+
+// waitForMaster pings the failover client with backoff, giving a
+// Sentinel quorum that's still mid-election a chance to settle before
+// we declare startup failed.
+func waitForMaster(ctx context.Context, client *redis.Client) error {
+	var lastErr error
+	for attempt := 0; attempt < sentinelDiscoveryRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(sentinelDiscoveryBackoff * time.Duration(attempt)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if lastErr = client.Ping(ctx).Err(); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+func splitAddrs(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	parts := strings.Split(raw, ",")
+	addrs := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			addrs = append(addrs, p)
+		}
+	}
+	return addrs
+}
+
+func intEnv(name string, def int) (int, error) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return v, nil
+}
+
+func tlsConfigFromEnv() (*tls.Config, error) {
+	if enabled, _ := strconv.ParseBool(os.Getenv("REDIS_TLS_ENABLED")); !enabled {
+		return nil, nil
+	}
+	cfg := &tls.Config{ServerName: os.Getenv("REDIS_TLS_SERVER_NAME")}
+	if skip, _ := strconv.ParseBool(os.Getenv("REDIS_TLS_INSECURE_SKIP_VERIFY")); skip {
+		cfg.InsecureSkipVerify = true
+	}
+	return cfg, nil
+}